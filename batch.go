@@ -1,49 +1,266 @@
+// Package gobatch implements a generic, concurrent batch processing
+// pipeline. Items are read from a source.Source, grouped into batches
+// according to a batch.Config, and handed to a processor.Processor for
+// processing.
 package gobatch
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
 	"sync"
 	"time"
 
+	"github.com/MasterOfBinary/gobatch/batch"
 	"github.com/MasterOfBinary/gobatch/processor"
 	"github.com/MasterOfBinary/gobatch/source"
 )
 
-type batchImpl struct {
-	minTime         time.Duration
-	minItems        uint64
-	maxTime         time.Duration
-	maxItems        uint64
-	readConcurrency uint64
+// ErrConcurrentGoCalls is sent on the errs channel returned by
+// StreamingBatch.Go when Go is called again before a previous call has
+// finished.
+var ErrConcurrentGoCalls = errors.New("gobatch: Go called while already running")
+
+// ErrCheckpointRequiresSingleReader is sent on the errs channel returned
+// by StreamingBatch.Go when WithCheckpointer is configured alongside a
+// readConcurrency greater than 1. Checkpointing assumes items are
+// observed in the order a single Source.Read produces them; with
+// multiple concurrent Read calls feeding the same batches, a
+// fast-arriving high-position item can be checkpointed before a
+// slow-arriving lower-position one is even read, permanently skipping
+// the latter on a future resume.
+var ErrCheckpointRequiresSingleReader = errors.New("gobatch: WithCheckpointer requires NewStreaming's readConcurrency to be 1")
+
+// Result holds the outcome of a single keyed job run with Batch.Go: the
+// value it produced, and any error it returned.
+type Result[T any] struct {
+	Value T
+	Err   error
+}
+
+// Batch runs keyed jobs concurrently and collects their results. It's an
+// ergonomic alternative to StreamingBatch for the common "run N jobs,
+// collect all outcomes" pattern, which otherwise requires writing a
+// custom source.Source. For a streaming pipeline backed by a
+// source.Source and a processor.Processor, use StreamingBatch instead.
+//
+// The zero value is not usable; create a Batch with New.
+type Batch[T any] struct {
+	ctx    context.Context
+	config batch.Config
+
+	mu      sync.Mutex
+	wg      sync.WaitGroup
+	sem     chan struct{}
+	results map[string]Result[T]
+}
+
+// New returns a Batch that runs jobs submitted to Go using ctx. config may
+// be nil, in which case batch.NewConstantConfig(nil) is used.
+//
+// ConfigValues.MaxItems, if non-zero, bounds the number of jobs that may
+// run concurrently; all other ConfigValues fields are ignored by Batch.
+func New[T any](ctx context.Context, config batch.Config) *Batch[T] {
+	if config == nil {
+		config = batch.NewConstantConfig(nil)
+	}
+
+	b := &Batch[T]{
+		ctx:    ctx,
+		config: config,
+	}
+
+	if max := config.Get().MaxItems; max > 0 {
+		b.sem = make(chan struct{}, max)
+	}
+
+	return b
+}
+
+// Go submits a keyed job to the Batch. fn is run in its own goroutine,
+// bounded by the concurrency configured in New; its result is collected
+// under key and returned by WaitAndGetResult. If Go is called twice with
+// the same key, the later result overwrites the earlier one.
+func (b *Batch[T]) Go(key string, fn func(ctx context.Context) (T, error)) {
+	b.mu.Lock()
+	if b.results == nil {
+		b.results = make(map[string]Result[T])
+	}
+	b.mu.Unlock()
+
+	b.wg.Add(1)
+	go func() {
+		defer b.wg.Done()
+
+		if b.sem != nil {
+			b.sem <- struct{}{}
+			defer func() { <-b.sem }()
+		}
+
+		value, err := fn(b.ctx)
+
+		b.mu.Lock()
+		b.results[key] = Result[T]{Value: value, Err: err}
+		b.mu.Unlock()
+	}()
+}
+
+// WaitAndGetResult blocks until every job submitted with Go has
+// completed, then returns their results keyed by the key each was
+// submitted with.
+func (b *Batch[T]) WaitAndGetResult() map[string]Result[T] {
+	b.wg.Wait()
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.results
+}
+
+// StreamingBatch reads items from a source.Source, groups them into
+// batches according to a batch.Config, and passes each batch to a
+// processor.Processor. It's the generic successor to the original
+// interface{}-based Batch; use Batch instead for the simpler "run N keyed
+// jobs" pattern.
+//
+// The zero value is not usable; create a StreamingBatch with NewStreaming.
+type StreamingBatch[T any] struct {
+	config            batch.Config
+	readConcurrency   uint64
+	sizer             func(item T) int
+	maxBufferedBytes  int
+	retryPolicy       *RetryPolicy
+	sourceReadTimeout time.Duration
+	checkpoint        checkpointSync[T]
 
 	running        bool
+	checkpointSkip func(item T) bool
 
-	src  source.Source
-	proc processor.Processor
+	src  source.Source[T]
+	proc processor.Processor[T]
 
-	items chan interface{}
+	items chan T
 	errs  chan error
 	done  chan struct{}
 
-	setupOnce sync.Once
-	mu        sync.Mutex
+	mu            sync.Mutex
+	cond          *sync.Cond
+	bufferedBytes int
+}
+
+// StreamingOption configures optional behavior of a StreamingBatch
+// created with NewStreaming.
+type StreamingOption[T any] func(*StreamingBatch[T])
+
+// WithSizer configures sizer as the function StreamingBatch uses to
+// measure the size, in bytes, of each item. It's required for
+// ConfigValues.MinBytes, ConfigValues.MaxBytes, and
+// WithMaxBufferedBytes to have any effect.
+func WithSizer[T any](sizer func(item T) int) StreamingOption[T] {
+	return func(b *StreamingBatch[T]) {
+		b.sizer = sizer
+	}
+}
+
+// WithMaxBufferedBytes bounds the total size, in bytes, of items that
+// have been read from the Source but not yet finished processing. Once
+// the bound is reached, Read blocks until enough bytes are released by
+// completed batches to make room. It has no effect unless a Sizer is
+// configured with WithSizer.
+func WithMaxBufferedBytes[T any](max int) StreamingOption[T] {
+	return func(b *StreamingBatch[T]) {
+		b.maxBufferedBytes = max
+	}
+}
+
+// WithRetryPolicy configures StreamingBatch to retry items that a
+// processor.Processor reports as failed via PartialFailure, following
+// policy. Without a RetryPolicy, a PartialFailure is forwarded on errs
+// like any other error and items are not retried.
+func WithRetryPolicy[T any](policy RetryPolicy) StreamingOption[T] {
+	return func(b *StreamingBatch[T]) {
+		b.retryPolicy = &policy
+	}
+}
+
+// WithSourceReadTimeout bounds how long a call to source.Source.Read may
+// go without sending an item or an error. If timeout elapses, the call's
+// context is canceled, a SourceStalledError is sent on errs, and Read is
+// called again from scratch. It has no effect if timeout is 0.
+func WithSourceReadTimeout[T any](timeout time.Duration) StreamingOption[T] {
+	return func(b *StreamingBatch[T]) {
+		b.sourceReadTimeout = timeout
+	}
+}
+
+// NewStreaming returns a StreamingBatch that reads with readConcurrency
+// concurrent calls to Source.Read. config may be nil, in which case
+// batch.NewConstantConfig(nil) is used. If readConcurrency is 0, it's
+// treated as 1. See WithCheckpointer for a restriction on
+// readConcurrency when checkpointing is used.
+func NewStreaming[T any](config batch.Config, readConcurrency uint64, opts ...StreamingOption[T]) *StreamingBatch[T] {
+	if config == nil {
+		config = batch.NewConstantConfig(nil)
+	}
+	if readConcurrency == 0 {
+		readConcurrency = 1
+	}
+
+	b := &StreamingBatch[T]{
+		config:          config,
+		readConcurrency: readConcurrency,
+		done:            make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(b)
+	}
+	b.cond = sync.NewCond(&b.mu)
+
+	return b
 }
 
-func (b *batchImpl) Go(ctx context.Context, s source.Source, p processor.Processor) <-chan error {
+// Go starts reading items from s and processing them with p. It returns a
+// channel on which errors are reported; the channel is closed once all
+// items have been read and processed. Go may not be called again until
+// the channel returned by Done is closed.
+func (b *StreamingBatch[T]) Go(ctx context.Context, s source.Source[T], p processor.Processor[T]) <-chan error {
 	b.mu.Lock()
 	defer b.mu.Unlock()
 
 	if b.running {
-		b.errs <- ErrConcurrentGoCalls
-		return b.errs
+		errs := make(chan error, 1)
+		errs <- ErrConcurrentGoCalls
+		close(errs)
+		return errs
+	}
+
+	if b.checkpoint != nil && b.readConcurrency > 1 {
+		errs := make(chan error, 1)
+		errs <- ErrCheckpointRequiresSingleReader
+		close(errs)
+		return errs
 	}
 
 	b.running = true
-	b.items = make(chan interface{})
+	b.items = make(chan T)
 	b.errs = make(chan error)
 	b.done = make(chan struct{})
 	b.src = s
 	b.proc = p
+	b.checkpointSkip = nil
+
+	if b.checkpoint != nil {
+		skip, err := b.checkpoint.load(ctx, s)
+		if err != nil {
+			b.running = false
+			errs := make(chan error, 1)
+			errs <- fmt.Errorf("gobatch: loading checkpoint: %w", err)
+			close(errs)
+			close(b.done)
+			return errs
+		}
+		b.checkpointSkip = skip
+	}
 
 	go b.doReaders(ctx)
 	go b.doProcessors(ctx)
@@ -51,81 +268,451 @@ func (b *batchImpl) Go(ctx context.Context, s source.Source, p processor.Process
 	return b.errs
 }
 
-func (b *batchImpl) Done() <-chan struct{} {
-    b.mu.Lock()
+// Done returns a channel that's closed once the current run started by Go
+// has finished processing all items.
+func (b *StreamingBatch[T]) Done() <-chan struct{} {
+	b.mu.Lock()
 	defer b.mu.Unlock()
-    return b.done
+	return b.done
 }
 
-func (b *batchImpl) doReaders(ctx context.Context) {
-	var cancel context.CancelFunc
-	ctx, cancel = context.WithCancel(ctx)
+func (b *StreamingBatch[T]) doReaders(ctx context.Context) {
+	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
-	
-    if readConcurrency > 0 {
-		var wg sync.WaitGroup
-		for i := 0; i < b.readConcurrency; i++ {
-			wg.Add(1)
-			go func() {
-				defer wg.Done()
-				read(ctx)
-			}()
-		}
-		wg.Wait()
-	} else {
-		err := errors.New("Read concurrency is 0")
-		b.errs <- err
-	}
 
-	b.mu.Lock()
-	close(b.items)
-	close(b.errs)
-	b.mu.Unlock()
+	// Only close items here; errs is closed by doProcessors once it's
+	// done processing, since it may still be writing to errs after
+	// reading has finished.
+	defer close(b.items)
+	defer b.recoverPanic()
+
+	var wg sync.WaitGroup
+	for i := uint64(0); i < b.readConcurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			// read runs in its own goroutine, so doReaders's own
+			// recoverPanic above can't see a panic here; recover it
+			// locally instead, e.g. one from a panicking Sizer in
+			// acquireBytes.
+			defer b.recoverPanic()
+			b.read(ctx)
+		}()
+	}
+	wg.Wait()
 }
 
-func (b *batchImpl) doProcessors(ctx context.Context) {
-	var cancel context.CancelFunc
-	ctx, cancel = context.WithCancel(ctx)
+func (b *StreamingBatch[T]) doProcessors(ctx context.Context) {
+	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
-	
-	// ...
 
-	// Once processors are complete, everything is
-	b.mu.Lock()
-	b.running = false
-	close(b.done)
-	b.mu.Unlock()
+	defer func() {
+		close(b.errs)
+
+		b.mu.Lock()
+		b.running = false
+		close(b.done)
+		b.mu.Unlock()
+	}()
+
+	// nextBatch, and any Sizer it calls, run directly in this goroutine
+	// rather than inside processBatch, so they need their own recover:
+	// without it, a panic here would crash the program instead of being
+	// reported as a PanicError.
+	defer b.recoverPanic()
+
+	cfg := b.config.Get()
+	if cfg.MinItems > cfg.MaxItems {
+		cfg.MinItems = cfg.MaxItems
+	}
+	if cfg.MinTime > cfg.MaxTime {
+		cfg.MinTime = cfg.MaxTime
+	}
+
+	for {
+		items, bytes, more := b.nextBatch(ctx, cfg)
+		if len(items) > 0 {
+			succeeded := b.processBatch(ctx, items)
+
+			if bytes > 0 {
+				b.mu.Lock()
+				b.bufferedBytes -= bytes
+				b.cond.Broadcast()
+				b.mu.Unlock()
+			}
+
+			if b.checkpoint != nil {
+				// Only items that actually finished processing advance
+				// the checkpoint; a failed or panicked item doesn't
+				// advance it by itself. The checkpoint is the highest
+				// position processed, though, so once a higher-position
+				// item succeeds, an earlier failed item is covered by it
+				// and skipped on the next run.
+				for _, item := range succeeded {
+					b.checkpoint.observe(item)
+				}
+				if err := b.checkpoint.flush(ctx); err != nil {
+					b.errs <- fmt.Errorf("gobatch: saving checkpoint: %w", err)
+				}
+			}
+		}
+
+		if !more {
+			break
+		}
+	}
+}
+
+// processBatch hands items to b.proc, following b.retryPolicy if one is
+// configured. A panic from the Processor is recovered, reported as a
+// PanicError on errs, and doesn't prevent doProcessors from moving on to
+// the next batch. It returns the items that were actually processed
+// successfully, i.e. excluding any a PartialFailure reported as failed,
+// and excluding every item if the batch panicked; this is what
+// checkpointing advances past.
+func (b *StreamingBatch[T]) processBatch(ctx context.Context, items []T) (succeeded []T) {
+	defer func() {
+		if r := recover(); r != nil {
+			b.errs <- &PanicError{Value: r, Stack: debug.Stack()}
+			succeeded = nil
+		}
+	}()
+
+	if b.retryPolicy != nil {
+		return b.processWithRetry(ctx, items)
+	}
+
+	failed := make(map[int]bool)
+
+	batchItems := make(chan T, len(items))
+	for _, item := range items {
+		batchItems <- item
+	}
+	close(batchItems)
+
+	batchErrs := make(chan error)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for err := range batchErrs {
+			var pf *PartialFailure
+			if errors.As(err, &pf) {
+				for _, idx := range pf.Indices {
+					failed[idx] = true
+				}
+			}
+			b.errs <- err
+		}
+	}()
+
+	b.proc.Process(ctx, batchItems, batchErrs)
+	close(batchErrs)
+	<-done
+
+	for i, item := range items {
+		if !failed[i] {
+			succeeded = append(succeeded, item)
+		}
+	}
+	return succeeded
+}
+
+// recoverPanic recovers a panic in the calling goroutine, if any, and
+// reports it on errs as a PanicError instead of letting it crash the
+// program.
+func (b *StreamingBatch[T]) recoverPanic() {
+	if r := recover(); r != nil {
+		b.errs <- &PanicError{Value: r, Stack: debug.Stack()}
+	}
+}
+
+// nextBatch accumulates items from b.items until the batch is ready to be
+// processed, as determined by cfg and, if a Sizer is configured, the
+// accumulated byte size of the items. It returns the items, their total
+// size in bytes, and whether b.items may still produce more items.
+func (b *StreamingBatch[T]) nextBatch(ctx context.Context, cfg batch.ConfigValues) (items []T, bytes int, more bool) {
+	var minTimer, maxTimer *time.Timer
+	var minTimeC, maxTimeC <-chan time.Time
+
+	if cfg.MinTime > 0 {
+		minTimer = time.NewTimer(cfg.MinTime)
+		defer minTimer.Stop()
+		minTimeC = minTimer.C
+	}
+	if cfg.MaxTime > 0 {
+		maxTimer = time.NewTimer(cfg.MaxTime)
+		defer maxTimer.Stop()
+		maxTimeC = maxTimer.C
+	}
+
+	minTimeReached := cfg.MinTime == 0
+
+	for {
+		maxReached := (cfg.MaxItems > 0 && uint64(len(items)) >= cfg.MaxItems) ||
+			(cfg.MaxBytes > 0 && bytes >= cfg.MaxBytes)
+		if maxReached {
+			return items, bytes, true
+		}
+
+		minReached := len(items) > 0 &&
+			minTimeReached &&
+			uint64(len(items)) >= cfg.MinItems &&
+			(b.sizer == nil || bytes >= cfg.MinBytes)
+		if minReached {
+			return items, bytes, true
+		}
+
+		select {
+		case item, ok := <-b.items:
+			if !ok {
+				return items, bytes, false
+			}
+			size := 0
+			if b.sizer != nil {
+				size = b.sizer(item)
+			}
+			items = append(items, item)
+			bytes += size
+
+		case <-minTimeC:
+			minTimeReached = true
+			minTimeC = nil
+
+		case <-maxTimeC:
+			return items, bytes, true
+
+		case <-ctx.Done():
+			return items, bytes, len(items) > 0
+		}
+	}
+}
+
+// read repeatedly calls b.src.Read until it completes without stalling or
+// ctx is done. If SourceReadTimeout is configured and a call makes no
+// progress within it, the call is canceled, a SourceStalledError is sent
+// on b.errs, and Read is called again from scratch.
+func (b *StreamingBatch[T]) read(ctx context.Context) {
+	for ctx.Err() == nil {
+		if !b.readOnce(ctx) {
+			return
+		}
+	}
 }
 
-func (b *batchImpl) read(ctx context.Context) {
-	items := make(chan interface{})
+// readOnce makes a single call to b.src.Read, forwarding items and errors
+// until the call completes. It returns true if the call stalled for
+// SourceReadTimeout and should be retried, or false once the call
+// completes normally or ctx is done.
+func (b *StreamingBatch[T]) readOnce(ctx context.Context) (stalled bool) {
+	readCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	items := make(chan T)
 	errs := make(chan error)
 
-	go b.src.Read(ctx, items, errs)
+	go b.runRead(readCtx, items, errs)
+
+	var timer *time.Timer
+	var timeoutC <-chan time.Time
+	resetTimer := func() {
+		if b.sourceReadTimeout <= 0 {
+			return
+		}
+		if timer != nil {
+			timer.Stop()
+		}
+		timer = time.NewTimer(b.sourceReadTimeout)
+		timeoutC = timer.C
+	}
+	resetTimer()
+	defer func() {
+		if timer != nil {
+			timer.Stop()
+		}
+	}()
 
 	// Read should close the channels when the context is done, so we don't check
 	// ctx.Done() here. Otherwise we might return before Read is completely
 	// finished. The way we know we've received everything from Read is
 	// when the channels have been closed.
 	var itemsClosed, errsClosed bool
-	for {
+	for !itemsClosed || !errsClosed {
 		select {
 		case item, ok := <-items:
-			if ok {
-				b.items <- item
-			} else {
+			if !ok {
 				itemsClosed = true
+				continue
 			}
+			resetTimer()
+			if b.checkpointSkip != nil && b.checkpointSkip(item) {
+				continue
+			}
+			b.acquireBytes(item)
+			b.items <- item
+			// Time spent blocked handing item downstream isn't a stall of
+			// the source, so the timeout restarts once it's through.
+			resetTimer()
+
 		case err, ok := <-errs:
-			if ok {
-			    wrappedErr := newSourceError(err)
-				b.errs <- wrappedErr
-			} else {
+			if !ok {
 				errsClosed = true
+				continue
+			}
+			b.errs <- err
+			resetTimer()
+
+		case <-timeoutC:
+			cancel()
+			b.errs <- &SourceStalledError{Timeout: b.sourceReadTimeout}
+			return true
+
+		case <-ctx.Done():
+			return false
+		}
+	}
+
+	return false
+}
+
+// runRead calls b.src.Read, recovering any panic and converting it into a
+// PanicError on errs. Read is expected to close items and errs before
+// returning; if it panics before doing so, runRead closes them on its
+// behalf so readOnce doesn't block forever.
+func (b *StreamingBatch[T]) runRead(ctx context.Context, items chan<- T, errs chan<- error) {
+	defer func() {
+		if r := recover(); r != nil {
+			errs <- &PanicError{Value: r, Stack: debug.Stack()}
+			close(items)
+			close(errs)
+		}
+	}()
+
+	b.src.Read(ctx, items, errs)
+}
+
+// acquireBytes accounts for item's size against maxBufferedBytes,
+// blocking until enough buffered bytes have been released by completed
+// batches to make room for it. It has no effect unless both a Sizer and
+// WithMaxBufferedBytes are configured.
+func (b *StreamingBatch[T]) acquireBytes(item T) {
+	if b.sizer == nil || b.maxBufferedBytes <= 0 {
+		return
+	}
+
+	size := b.sizer(item)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for b.bufferedBytes > 0 && b.bufferedBytes+size > b.maxBufferedBytes {
+		b.cond.Wait()
+	}
+	b.bufferedBytes += size
+}
+
+// processWithRetry processes items, following b.retryPolicy to retry any
+// items a PartialFailure reports as failed, until they succeed, are
+// reported as not retryable, or exhaust retryPolicy.MaxAttempts. If ctx
+// is canceled while waiting out a retry delay, every item still pending
+// a retry is reported as a RetryExhaustedError with ctx.Err() instead of
+// being silently abandoned. It returns the items that ended up
+// succeeding, i.e. excluding any reported via RetryExhaustedError; this
+// is what checkpointing advances past.
+func (b *StreamingBatch[T]) processWithRetry(ctx context.Context, items []T) (succeeded []T) {
+	attempts := make([]int, len(items))
+	resolved := make([]bool, len(items))
+	pending := make([]int, len(items))
+	for i := range pending {
+		pending[i] = i
+	}
+
+	for len(pending) > 0 {
+		batchItems := make(chan T, len(pending))
+		for _, idx := range pending {
+			batchItems <- items[idx]
+		}
+		close(batchItems)
+
+		batchErrs := make(chan error)
+		failed := make(map[int]error)
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			for err := range batchErrs {
+				var pf *PartialFailure
+				if errors.As(err, &pf) {
+					for _, relIdx := range pf.Indices {
+						failed[relIdx] = pf.Err
+					}
+				} else {
+					b.errs <- err
+				}
+			}
+		}()
+
+		b.proc.Process(ctx, batchItems, batchErrs)
+		close(batchErrs)
+		<-done
+
+		if len(failed) == 0 {
+			break
+		}
+
+		var next []int
+		canceled := false
+		for relIdx, err := range failed {
+			idx := pending[relIdx]
+
+			// Once canceled, report every remaining item in this round
+			// the same way, rather than stopping partway through
+			// map-iteration order (which is undefined) and silently
+			// dropping whatever wasn't visited yet.
+			if canceled {
+				resolved[idx] = true
+				b.errs <- &RetryExhaustedError[T]{Item: items[idx], Err: ctx.Err()}
+				continue
+			}
+
+			attempts[idx]++
+			if !b.retryPolicy.retryable(err) || attempts[idx] >= b.retryPolicy.MaxAttempts {
+				resolved[idx] = true
+				b.errs <- &RetryExhaustedError[T]{Item: items[idx], Err: err}
+				continue
+			}
+
+			select {
+			case <-time.After(b.retryPolicy.delay(attempts[idx])):
+				next = append(next, idx)
+			case <-ctx.Done():
+				canceled = true
+				resolved[idx] = true
+				b.errs <- &RetryExhaustedError[T]{Item: items[idx], Err: ctx.Err()}
 			}
 		}
-		if itemsClosed && errsClosed {
+
+		if canceled {
+			// Items that already finished waiting out their delay this
+			// round, before cancellation was noticed, were about to be
+			// retried; surface them too instead of leaving them
+			// pending forever.
+			for _, idx := range next {
+				resolved[idx] = true
+				b.errs <- &RetryExhaustedError[T]{Item: items[idx], Err: ctx.Err()}
+			}
 			break
 		}
+
+		pending = next
 	}
-}
\ No newline at end of file
+
+	return itemsWhere(items, resolved, false)
+}
+
+// itemsWhere returns the items at indices where resolved is want.
+func itemsWhere[T any](items []T, resolved []bool, want bool) (result []T) {
+	for i, item := range items {
+		if resolved[i] == want {
+			result = append(result, item)
+		}
+	}
+	return result
+}
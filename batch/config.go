@@ -41,6 +41,18 @@ type ConfigValues struct {
 	// before processing. Once that number of items is available, they will
 	// be processed whether or not MinTime has been reached.
 	MaxItems uint64 `json:"maxItems"`
+
+	// MinBytes specifies that a minimum accumulated item size, in bytes,
+	// should be ready before processing. It behaves like MinItems, but
+	// measures the size of items (as reported by a Sizer) rather than
+	// their count. It has no effect unless a Sizer is configured.
+	MinBytes int `json:"minBytes"`
+
+	// MaxBytes specifies that a maximum accumulated item size, in bytes,
+	// should be available before processing. It behaves like MaxItems,
+	// but measures the size of items (as reported by a Sizer) rather
+	// than their count. It has no effect unless a Sizer is configured.
+	MaxBytes int `json:"maxBytes"`
 }
 
 // NewConstantConfig returns a Config with constant values. If values
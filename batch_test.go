@@ -1,27 +1,607 @@
 package gobatch
 
 import (
+	"context"
 	"errors"
+	"sync"
 	"testing"
+	"time"
+
+	"github.com/MasterOfBinary/gobatch/batch"
+	"github.com/MasterOfBinary/gobatch/checkpoint"
 )
 
-func TestMust(t *testing.T) {
-	batch := &MockBatch{}
-	if Must(batch, nil) != batch {
-		t.Error("Must(batch, nil) != batch")
+// sliceSource reads every element of items, once, across however many
+// concurrent Read calls StreamingBatch makes.
+type sliceSource struct {
+	mu    sync.Mutex
+	items []int
+}
+
+func (s *sliceSource) Read(ctx context.Context, items chan<- int, errs chan<- error) {
+	defer close(items)
+	defer close(errs)
+
+	for {
+		s.mu.Lock()
+		if len(s.items) == 0 {
+			s.mu.Unlock()
+			return
+		}
+		item := s.items[0]
+		s.items = s.items[1:]
+		s.mu.Unlock()
+
+		select {
+		case items <- item:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// batchCountingProcessor records the size of every batch it's given.
+type batchCountingProcessor struct {
+	mu    sync.Mutex
+	sizes []int
+}
+
+func (p *batchCountingProcessor) Process(ctx context.Context, items <-chan int, errs chan<- error) {
+	size := 0
+	for range items {
+		size++
+	}
+
+	p.mu.Lock()
+	p.sizes = append(p.sizes, size)
+	p.mu.Unlock()
+}
+
+// blockingSizeProcessor records each item it's given and then blocks
+// until release is sent to, so a test can control exactly when a
+// batch's bytes are released back to WithMaxBufferedBytes.
+type blockingSizeProcessor struct {
+	mu      sync.Mutex
+	started []int
+	release chan struct{}
+}
+
+func (p *blockingSizeProcessor) Process(ctx context.Context, items <-chan int, errs chan<- error) {
+	for item := range items {
+		p.mu.Lock()
+		p.started = append(p.started, item)
+		p.mu.Unlock()
+
+		<-p.release
+	}
+}
+
+func (p *blockingSizeProcessor) count() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.started)
+}
+
+// waitForCount polls count until it returns want, failing the test if it
+// doesn't within a second.
+func waitForCount(t *testing.T, count func() int, want int) {
+	t.Helper()
+
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		if count() == want {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatalf("timed out waiting for count %d, got %d", want, count())
+}
+
+func TestStreamingBatchMaxItems(t *testing.T) {
+	src := &sliceSource{items: []int{1, 2, 3, 4, 5}}
+	proc := &batchCountingProcessor{}
+
+	config := batch.NewConstantConfig(&batch.ConfigValues{
+		MaxItems: 2,
+		MaxTime:  100 * time.Millisecond,
+	})
+
+	b := NewStreaming[int](config, 1)
+	errs := b.Go(context.Background(), src, proc)
+	for range errs {
+	}
+	<-b.Done()
+
+	for _, size := range proc.sizes {
+		if size > 2 {
+			t.Errorf("batch size %d exceeds MaxItems of 2", size)
+		}
+	}
+
+	total := 0
+	for _, size := range proc.sizes {
+		total += size
+	}
+	if total != 5 {
+		t.Errorf("got %d items processed, want 5", total)
+	}
+}
+
+func TestStreamingBatchMaxBytes(t *testing.T) {
+	src := &sliceSource{items: []int{1, 2, 3, 4, 5}}
+	proc := &batchCountingProcessor{}
+
+	config := batch.NewConstantConfig(&batch.ConfigValues{
+		// MinItems equal to MaxItems keeps the min-items path from ever
+		// triggering a flush on its own, so MaxBytes is what actually
+		// decides batch boundaries below; MaxTime is just a safety net.
+		MinItems: 5,
+		MaxItems: 5,
+		MaxBytes: 5,
+		MaxTime:  100 * time.Millisecond,
+	})
+
+	b := NewStreaming[int](config, 1, WithSizer[int](func(item int) int { return item }))
+	errs := b.Go(context.Background(), src, proc)
+	for range errs {
+	}
+	<-b.Done()
+
+	proc.mu.Lock()
+	defer proc.mu.Unlock()
+
+	// Items 1+2+3 reach MaxBytes of 5 first, then 4+5 do; item count
+	// never factors in since the batches (3, 2) fall short of MaxItems.
+	want := []int{3, 2}
+	if len(proc.sizes) != len(want) {
+		t.Fatalf("got %d batches %v, want %d batches %v", len(proc.sizes), proc.sizes, len(want), want)
+	}
+	for i, size := range want {
+		if proc.sizes[i] != size {
+			t.Errorf("batch %d: got %d items, want %d", i, proc.sizes[i], size)
+		}
+	}
+}
+
+func TestStreamingBatchMaxBufferedBytesBlocksUntilReleased(t *testing.T) {
+	src := &sliceSource{items: []int{1, 2, 3}}
+	proc := &blockingSizeProcessor{release: make(chan struct{})}
+
+	config := batch.NewConstantConfig(&batch.ConfigValues{
+		MaxItems: 1,
+	})
+
+	b := NewStreaming[int](config, 1,
+		WithSizer[int](func(item int) int { return 10 }),
+		WithMaxBufferedBytes[int](10),
+	)
+
+	errs := b.Go(context.Background(), src, proc)
+	go func() {
+		for range errs {
+		}
+	}()
+
+	waitForCount(t, proc.count, 1)
+
+	// The first item's 10 bytes are still held (it hasn't finished
+	// processing), and a second item's 10 bytes would push bufferedBytes
+	// past MaxBufferedBytes of 10, so the reader should be blocked in
+	// acquireBytes no matter how long we give it.
+	time.Sleep(20 * time.Millisecond)
+	if got := proc.count(); got != 1 {
+		t.Fatalf("got %d item(s) started, want 1 (second item should be blocked by MaxBufferedBytes)", got)
+	}
+
+	// Releasing the first item's batch frees its bytes, unblocking the
+	// second; same again for the third.
+	proc.release <- struct{}{}
+	waitForCount(t, proc.count, 2)
+	proc.release <- struct{}{}
+	waitForCount(t, proc.count, 3)
+	proc.release <- struct{}{}
+
+	<-b.Done()
+}
+
+// flakyProcessor fails item 1 once and item 2 on every attempt, reporting
+// both via PartialFailure.
+type flakyProcessor struct {
+	mu       sync.Mutex
+	attempts map[int]int
+}
+
+func (p *flakyProcessor) Process(ctx context.Context, items <-chan int, errs chan<- error) {
+	var received []int
+	for item := range items {
+		received = append(received, item)
+	}
+
+	var indices []int
+	for i, item := range received {
+		p.mu.Lock()
+		p.attempts[item]++
+		attempt := p.attempts[item]
+		p.mu.Unlock()
+
+		if item == 1 && attempt == 1 {
+			indices = append(indices, i)
+		} else if item == 2 {
+			indices = append(indices, i)
+		}
+	}
+
+	if len(indices) > 0 {
+		errs <- &PartialFailure{Err: errors.New("flaky failure"), Indices: indices}
+	}
+}
+
+func TestStreamingBatchRetryPolicy(t *testing.T) {
+	src := &sliceSource{items: []int{1, 2}}
+	proc := &flakyProcessor{attempts: make(map[int]int)}
+
+	config := batch.NewConstantConfig(&batch.ConfigValues{
+		MaxItems: 2,
+		MaxTime:  100 * time.Millisecond,
+	})
+
+	b := NewStreaming[int](config, 1, WithRetryPolicy[int](RetryPolicy{
+		MaxAttempts: 2,
+		BaseDelay:   time.Millisecond,
+		MaxDelay:    10 * time.Millisecond,
+	}))
+
+	var exhausted *RetryExhaustedError[int]
+	errs := b.Go(context.Background(), src, proc)
+	for err := range errs {
+		if !errors.As(err, &exhausted) {
+			t.Errorf("unexpected error: %v", err)
+		}
+	}
+	<-b.Done()
+
+	if exhausted == nil || exhausted.Item != 2 {
+		t.Errorf("got exhausted=%v, want a RetryExhaustedError for item 2", exhausted)
+	}
+
+	proc.mu.Lock()
+	defer proc.mu.Unlock()
+	if proc.attempts[1] != 2 {
+		t.Errorf("item 1 got %d attempts, want 2 (fail once, then succeed)", proc.attempts[1])
+	}
+	if proc.attempts[2] != 2 {
+		t.Errorf("item 2 got %d attempts, want 2 (MaxAttempts)", proc.attempts[2])
+	}
+}
+
+// alwaysFailAllProcessor reports every item in every batch as failed
+// via PartialFailure, forever, and closes called after its first call.
+type alwaysFailAllProcessor struct {
+	called     chan struct{}
+	closedOnce sync.Once
+}
+
+func (p *alwaysFailAllProcessor) Process(ctx context.Context, items <-chan int, errs chan<- error) {
+	var indices []int
+	i := 0
+	for range items {
+		indices = append(indices, i)
+		i++
 	}
+	p.closedOnce.Do(func() { close(p.called) })
+	errs <- &PartialFailure{Err: errors.New("always fails"), Indices: indices}
+}
+
+func TestStreamingBatchRetryAbandonsPendingOnCancel(t *testing.T) {
+	src := &sliceSource{items: []int{1, 2, 3}}
+	proc := &alwaysFailAllProcessor{called: make(chan struct{})}
+
+	config := batch.NewConstantConfig(&batch.ConfigValues{
+		MinItems: 3,
+		MaxItems: 3,
+		MaxTime:  100 * time.Millisecond,
+	})
+
+	// A BaseDelay this long means the retry wait will never elapse on
+	// its own within the test; it only proceeds via ctx's cancellation.
+	b := NewStreaming[int](config, 1, WithRetryPolicy[int](RetryPolicy{
+		MaxAttempts: 1000,
+		BaseDelay:   time.Hour,
+	}))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errs := b.Go(ctx, src, proc)
 
-	var panics bool
-	func() {
-		defer func() {
-			if p := recover(); p != nil {
-				panics = true
-			}
-		}()
-		_ = Must(&MockBatch{}, errors.New("error"))
+	go func() {
+		<-proc.called
+		cancel()
 	}()
 
-	if !panics {
-		t.Error("Must(batch, err) doesn't panic")
+	var exhausted int
+	for err := range errs {
+		var re *RetryExhaustedError[int]
+		if !errors.As(err, &re) {
+			t.Errorf("unexpected error: %v", err)
+			continue
+		}
+		if !errors.Is(re, context.Canceled) {
+			t.Errorf("RetryExhaustedError.Err = %v, want context.Canceled", re.Err)
+		}
+		exhausted++
+	}
+	<-b.Done()
+
+	if exhausted != 3 {
+		t.Errorf("got %d RetryExhaustedError, want 3 (every pending item reported on cancellation)", exhausted)
+	}
+}
+
+// panickyProcessor panics the first time it's given a batch, then
+// behaves like batchCountingProcessor on every later call.
+type panickyProcessor struct {
+	batchCountingProcessor
+	panicked bool
+}
+
+func (p *panickyProcessor) Process(ctx context.Context, items <-chan int, errs chan<- error) {
+	if !p.panicked {
+		p.panicked = true
+		for range items {
+		}
+		panic("boom")
+	}
+	p.batchCountingProcessor.Process(ctx, items, errs)
+}
+
+func TestStreamingBatchRecoversProcessorPanic(t *testing.T) {
+	src := &sliceSource{items: []int{1, 2, 3, 4}}
+	proc := &panickyProcessor{}
+
+	config := batch.NewConstantConfig(&batch.ConfigValues{
+		MinItems: 2,
+		MaxItems: 2,
+		MaxTime:  100 * time.Millisecond,
+	})
+
+	b := NewStreaming[int](config, 1)
+
+	var panicErr *PanicError
+	errs := b.Go(context.Background(), src, proc)
+	for err := range errs {
+		if errors.As(err, &panicErr) {
+			continue
+		}
+		t.Errorf("unexpected error: %v", err)
+	}
+	<-b.Done()
+
+	if panicErr == nil {
+		t.Fatal("expected a PanicError, got none")
+	}
+
+	proc.mu.Lock()
+	defer proc.mu.Unlock()
+	total := 0
+	for _, size := range proc.sizes {
+		total += size
+	}
+	if total != 2 {
+		t.Errorf("got %d items processed after the panic, want 2", total)
+	}
+}
+
+func TestStreamingBatchRecoversNextBatchSizerPanic(t *testing.T) {
+	src := &sliceSource{items: []int{1, 2, 3, 4}}
+	proc := &batchCountingProcessor{}
+
+	sizer := func(item int) int {
+		if item == 3 {
+			panic("boom")
+		}
+		return item
+	}
+
+	b := NewStreaming[int](nil, 1, WithSizer[int](sizer))
+
+	var panicErr *PanicError
+	errs := b.Go(context.Background(), src, proc)
+	for err := range errs {
+		if !errors.As(err, &panicErr) {
+			t.Errorf("unexpected error: %v", err)
+		}
+	}
+	<-b.Done()
+
+	if panicErr == nil {
+		t.Fatal("expected a PanicError, got none")
+	}
+}
+
+// checkpointableSliceSource is a sliceSource whose items are their own
+// checkpoint position.
+type checkpointableSliceSource struct {
+	sliceSource
+}
+
+func (s *checkpointableSliceSource) Position(item int) int {
+	return item
+}
+
+func TestStreamingBatchResumesFromCheckpoint(t *testing.T) {
+	src := &checkpointableSliceSource{sliceSource{items: []int{1, 2, 3, 4}}}
+	proc := &batchCountingProcessor{}
+
+	checkpointer := checkpoint.NewInMemoryCheckpointer[int]()
+	if err := checkpointer.Save(context.Background(), 2); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	config := batch.NewConstantConfig(&batch.ConfigValues{
+		MaxItems: 1,
+		MaxTime:  100 * time.Millisecond,
+	})
+
+	b := NewStreaming[int](config, 1, WithCheckpointer[int, int](checkpointer))
+	errs := b.Go(context.Background(), src, proc)
+	for err := range errs {
+		t.Errorf("unexpected error: %v", err)
+	}
+	<-b.Done()
+
+	var processed []int
+	proc.mu.Lock()
+	for _, size := range proc.sizes {
+		if size > 0 {
+			processed = append(processed, size)
+		}
+	}
+	proc.mu.Unlock()
+
+	total := 0
+	for _, size := range processed {
+		total += size
+	}
+	if total != 2 {
+		t.Errorf("got %d items processed, want 2 (items 3 and 4 only)", total)
+	}
+
+	pos, err := checkpointer.Load(context.Background())
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if pos != 4 {
+		t.Errorf("got checkpoint %d, want 4", pos)
+	}
+}
+
+// alwaysFailProcessor reports every item in a batch as failed via
+// PartialFailure, on every attempt.
+type alwaysFailProcessor struct{}
+
+func (p *alwaysFailProcessor) Process(ctx context.Context, items <-chan int, errs chan<- error) {
+	var indices []int
+	i := 0
+	for range items {
+		indices = append(indices, i)
+		i++
+	}
+	errs <- &PartialFailure{Err: errors.New("permanent failure"), Indices: indices}
+}
+
+func TestStreamingBatchCheckpointSkipsPermanentlyFailedItems(t *testing.T) {
+	src := &checkpointableSliceSource{sliceSource{items: []int{1, 2}}}
+	proc := &alwaysFailProcessor{}
+
+	checkpointer := checkpoint.NewInMemoryCheckpointer[int]()
+
+	config := batch.NewConstantConfig(&batch.ConfigValues{
+		MaxItems: 2,
+		MaxTime:  100 * time.Millisecond,
+	})
+
+	b := NewStreaming[int](config, 1,
+		WithCheckpointer[int, int](checkpointer),
+		WithRetryPolicy[int](RetryPolicy{MaxAttempts: 1}),
+	)
+
+	var exhausted int
+	errs := b.Go(context.Background(), src, proc)
+	for err := range errs {
+		var re *RetryExhaustedError[int]
+		if errors.As(err, &re) {
+			exhausted++
+			continue
+		}
+		t.Errorf("unexpected error: %v", err)
+	}
+	<-b.Done()
+
+	if exhausted != 2 {
+		t.Fatalf("got %d RetryExhaustedError, want 2", exhausted)
+	}
+
+	if _, err := checkpointer.Load(context.Background()); !errors.Is(err, checkpoint.ErrNotFound) {
+		t.Errorf("checkpoint Load: got err %v, want ErrNotFound (nothing should have been checkpointed)", err)
+	}
+}
+
+func TestStreamingBatchCheckpointRequiresSingleReader(t *testing.T) {
+	src := &checkpointableSliceSource{sliceSource{items: []int{1, 2}}}
+	proc := &batchCountingProcessor{}
+	checkpointer := checkpoint.NewInMemoryCheckpointer[int]()
+
+	b := NewStreaming[int](nil, 2, WithCheckpointer[int, int](checkpointer))
+	errs := b.Go(context.Background(), src, proc)
+
+	var got []error
+	for err := range errs {
+		got = append(got, err)
+	}
+
+	if len(got) != 1 || !errors.Is(got[0], ErrCheckpointRequiresSingleReader) {
+		t.Fatalf("got errors %v, want exactly [ErrCheckpointRequiresSingleReader]", got)
+	}
+}
+
+// stallOnceSource does nothing but wait for ctx to be done on its first
+// Read call, then produces items normally on every call after that, so
+// tests can exercise the SourceStalledError/restart path.
+type stallOnceSource struct {
+	mu    sync.Mutex
+	calls int
+
+	sliceSource
+}
+
+func (s *stallOnceSource) Read(ctx context.Context, items chan<- int, errs chan<- error) {
+	s.mu.Lock()
+	call := s.calls
+	s.calls++
+	s.mu.Unlock()
+
+	if call == 0 {
+		defer close(items)
+		defer close(errs)
+		<-ctx.Done()
+		return
+	}
+
+	s.sliceSource.Read(ctx, items, errs)
+}
+
+func TestStreamingBatchRestartsAfterSourceStall(t *testing.T) {
+	src := &stallOnceSource{sliceSource: sliceSource{items: []int{1, 2}}}
+	proc := &batchCountingProcessor{}
+
+	b := NewStreaming[int](nil, 1, WithSourceReadTimeout[int](20*time.Millisecond))
+
+	var stalled *SourceStalledError
+	errs := b.Go(context.Background(), src, proc)
+	for err := range errs {
+		if !errors.As(err, &stalled) {
+			t.Errorf("unexpected error: %v", err)
+		}
+	}
+	<-b.Done()
+
+	if stalled == nil {
+		t.Fatal("expected a SourceStalledError, got none")
+	}
+
+	src.mu.Lock()
+	calls := src.calls
+	src.mu.Unlock()
+	if calls != 2 {
+		t.Errorf("got %d Read calls, want 2 (the stalled call, then a restart)", calls)
+	}
+
+	total := 0
+	proc.mu.Lock()
+	for _, size := range proc.sizes {
+		total += size
+	}
+	proc.mu.Unlock()
+	if total != 2 {
+		t.Errorf("got %d items processed, want 2 (both items from the restarted Read)", total)
 	}
-}
\ No newline at end of file
+}
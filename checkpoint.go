@@ -0,0 +1,127 @@
+package gobatch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/MasterOfBinary/gobatch/checkpoint"
+	"github.com/MasterOfBinary/gobatch/source"
+)
+
+// ordered constrains the position types usable with WithCheckpointer to
+// ones that can be compared with < and <=, which is how StreamingBatch
+// tracks the highest position processed and decides which items a saved
+// checkpoint has already covered.
+type ordered interface {
+	~int | ~int8 | ~int16 | ~int32 | ~int64 |
+		~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64 | ~uintptr |
+		~float32 | ~float64 | ~string
+}
+
+// checkpointSync is the type-erased interface StreamingBatch uses to
+// drive a checkpointState of whatever position type it was created with.
+type checkpointSync[T any] interface {
+	// load restores the saved position, if any, from the checkpointer;
+	// src must implement source.Checkpointable[T, P]. It returns a predicate
+	// reporting whether an item has already been processed according to
+	// the saved position; the predicate always reports false if src
+	// implements source.Seekable[P], since the source itself skips
+	// ahead in that case.
+	load(ctx context.Context, src any) (alreadyProcessed func(item T) bool, err error)
+
+	// observe records that item has finished processing, updating the
+	// in-memory high-water mark.
+	observe(item T)
+
+	// flush persists the current high-water mark.
+	flush(ctx context.Context) error
+}
+
+type checkpointState[T any, P ordered] struct {
+	checkpointer checkpoint.Checkpointer[P]
+	position     func(item T) P
+
+	mu      sync.Mutex
+	has     bool
+	highest P
+}
+
+func (s *checkpointState[T, P]) load(ctx context.Context, src any) (func(item T) bool, error) {
+	checkpointable, ok := src.(source.Checkpointable[T, P])
+	if !ok {
+		return nil, fmt.Errorf("gobatch: source does not implement source.Checkpointable[T, P]")
+	}
+	s.position = checkpointable.Position
+
+	pos, err := s.checkpointer.Load(ctx)
+	if errors.Is(err, checkpoint.ErrNotFound) {
+		return func(T) bool { return false }, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	s.has = true
+	s.highest = pos
+	s.mu.Unlock()
+
+	if seekable, ok := src.(source.Seekable[P]); ok {
+		if err := seekable.SeekTo(ctx, pos); err != nil {
+			return nil, err
+		}
+		return func(T) bool { return false }, nil
+	}
+
+	return func(item T) bool {
+		return checkpointable.Position(item) <= pos
+	}, nil
+}
+
+func (s *checkpointState[T, P]) observe(item T) {
+	if s.position == nil {
+		return
+	}
+	p := s.position(item)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.has || s.highest < p {
+		s.highest = p
+		s.has = true
+	}
+}
+
+func (s *checkpointState[T, P]) flush(ctx context.Context) error {
+	s.mu.Lock()
+	has := s.has
+	pos := s.highest
+	s.mu.Unlock()
+
+	if !has {
+		return nil
+	}
+	return s.checkpointer.Save(ctx, pos)
+}
+
+// WithCheckpointer configures StreamingBatch to resume from, and persist
+// progress to, checkpointer. The Source passed to Go must implement
+// source.Checkpointable[T, P]; if it also implements source.Seekable[P],
+// StreamingBatch asks it to seek to the saved position instead of
+// filtering out already-processed items itself.
+//
+// After each batch finishes processing, the highest position among the
+// items processed successfully so far is saved with checkpointer. If Go's context is canceled, in-flight
+// batches finish and their positions are saved before Done is closed.
+//
+// Checkpointable.Position documents positions as increasing
+// monotonically in the order Read produces items, which only holds with
+// a single reader: Go returns ErrCheckpointRequiresSingleReader instead
+// of running if NewStreaming's readConcurrency is greater than 1.
+func WithCheckpointer[T any, P ordered](checkpointer checkpoint.Checkpointer[P]) StreamingOption[T] {
+	return func(b *StreamingBatch[T]) {
+		b.checkpoint = &checkpointState[T, P]{checkpointer: checkpointer}
+	}
+}
@@ -0,0 +1,119 @@
+// Package checkpoint provides Checkpointer implementations that let a
+// gobatch StreamingBatch run resume where a previous one left off.
+package checkpoint
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"sync"
+)
+
+// ErrNotFound is returned by Checkpointer.Load when no checkpoint has
+// been saved yet.
+var ErrNotFound = errors.New("checkpoint: not found")
+
+// Checkpointer persists and restores the position, of type P, that a
+// StreamingBatch has processed up to.
+type Checkpointer[P any] interface {
+	// Save persists state as the latest processed position.
+	Save(ctx context.Context, state P) error
+
+	// Load returns the most recently saved position. It returns
+	// ErrNotFound if none has been saved yet.
+	Load(ctx context.Context) (P, error)
+}
+
+// InMemoryCheckpointer is a Checkpointer that keeps its state in memory.
+// It's useful for tests, or for processes that only need to resume
+// within their own lifetime.
+//
+// The zero value has no saved state; use NewInMemoryCheckpointer.
+type InMemoryCheckpointer[P any] struct {
+	mu    sync.Mutex
+	state P
+	saved bool
+}
+
+// NewInMemoryCheckpointer returns an InMemoryCheckpointer with no saved
+// state.
+func NewInMemoryCheckpointer[P any]() *InMemoryCheckpointer[P] {
+	return &InMemoryCheckpointer[P]{}
+}
+
+// Save implements Checkpointer.
+func (c *InMemoryCheckpointer[P]) Save(ctx context.Context, state P) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.state = state
+	c.saved = true
+	return nil
+}
+
+// Load implements Checkpointer.
+func (c *InMemoryCheckpointer[P]) Load(ctx context.Context) (P, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.saved {
+		var zero P
+		return zero, ErrNotFound
+	}
+	return c.state, nil
+}
+
+// FileCheckpointer is a Checkpointer that persists its state as JSON in a
+// file, so a batch run can resume after a process restart.
+//
+// The zero value is not usable; create one with NewFileCheckpointer.
+type FileCheckpointer[P any] struct {
+	path string
+	mu   sync.Mutex
+}
+
+// NewFileCheckpointer returns a FileCheckpointer that persists to path.
+func NewFileCheckpointer[P any](path string) *FileCheckpointer[P] {
+	return &FileCheckpointer[P]{path: path}
+}
+
+// Save implements Checkpointer, writing state to the file as JSON. The
+// write is done via a temporary file and rename so a crash mid-write
+// can't leave a truncated checkpoint behind.
+func (c *FileCheckpointer[P]) Save(ctx context.Context, state P) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	data, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+
+	tmp := c.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, c.path)
+}
+
+// Load implements Checkpointer, reading state from the file. It returns
+// ErrNotFound if the file doesn't exist.
+func (c *FileCheckpointer[P]) Load(ctx context.Context) (P, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var state P
+	data, err := os.ReadFile(c.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return state, ErrNotFound
+		}
+		return state, err
+	}
+
+	if err := json.Unmarshal(data, &state); err != nil {
+		return state, err
+	}
+	return state, nil
+}
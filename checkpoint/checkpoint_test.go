@@ -0,0 +1,60 @@
+package checkpoint
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+func TestInMemoryCheckpointer(t *testing.T) {
+	c := NewInMemoryCheckpointer[int]()
+
+	if _, err := c.Load(context.Background()); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Load before Save: got err %v, want ErrNotFound", err)
+	}
+
+	if err := c.Save(context.Background(), 42); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	got, err := c.Load(context.Background())
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if got != 42 {
+		t.Errorf("got %d, want 42", got)
+	}
+}
+
+func TestFileCheckpointer(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "checkpoint.json")
+	c := NewFileCheckpointer[int](path)
+
+	if _, err := c.Load(context.Background()); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Load before Save: got err %v, want ErrNotFound", err)
+	}
+
+	if err := c.Save(context.Background(), 7); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	got, err := c.Load(context.Background())
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if got != 7 {
+		t.Errorf("got %d, want 7", got)
+	}
+
+	// A fresh checkpointer pointed at the same file should see the saved
+	// state too.
+	c2 := NewFileCheckpointer[int](path)
+	got2, err := c2.Load(context.Background())
+	if err != nil {
+		t.Fatalf("Load (fresh checkpointer): %v", err)
+	}
+	if got2 != 7 {
+		t.Errorf("got %d, want 7", got2)
+	}
+}
@@ -0,0 +1,232 @@
+// Package pool provides a bounded worker-pool processor.Processor-like
+// sink, built on top of the same batch.Config windowing gobatch uses
+// elsewhere, for users who just want to export batches concurrently
+// without writing a custom processor.Processor and concurrency control
+// themselves.
+package pool
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/MasterOfBinary/gobatch/batch"
+)
+
+// ErrClosed is returned by Enqueue once Shutdown has been called.
+var ErrClosed = errors.New("pool: closed")
+
+// Pool groups items enqueued with Enqueue into batches according to a
+// batch.Config, and hands each batch to an exportFunc, running up to
+// maxWorkers of them concurrently.
+//
+// The zero value is not usable; create a Pool with New.
+type Pool[T any] struct {
+	config     batch.Config
+	maxWorkers int
+	exportFunc func(ctx context.Context, items []T) error
+
+	items chan T
+	sem   chan struct{}
+	wg    sync.WaitGroup
+
+	stopCh chan struct{}
+	done   chan struct{}
+
+	mu     sync.Mutex
+	closed bool
+	errs   []error
+}
+
+// New returns a Pool that queues up to queueSize items without blocking
+// Enqueue, groups them into batches according to config, and processes
+// up to maxWorkers batches concurrently by calling exportFunc. config
+// may be nil, in which case batch.NewConstantConfig(nil) is used. If
+// maxWorkers is 0 or negative, it's treated as 1.
+func New[T any](config batch.Config, queueSize, maxWorkers int, exportFunc func(ctx context.Context, items []T) error) *Pool[T] {
+	if config == nil {
+		config = batch.NewConstantConfig(nil)
+	}
+	if maxWorkers <= 0 {
+		maxWorkers = 1
+	}
+	if queueSize < 0 {
+		queueSize = 0
+	}
+
+	p := &Pool[T]{
+		config:     config,
+		maxWorkers: maxWorkers,
+		exportFunc: exportFunc,
+		items:      make(chan T, queueSize),
+		sem:        make(chan struct{}, maxWorkers),
+		stopCh:     make(chan struct{}),
+		done:       make(chan struct{}),
+	}
+
+	go p.run()
+
+	return p
+}
+
+// Enqueue adds item to the Pool's queue, blocking if it's full. It
+// returns ErrClosed if Shutdown has already been called. The blocking
+// part of the send is done without holding p.mu: if it were held, a
+// blocked Enqueue (e.g. a full queue while every worker is busy) would
+// also block Shutdown from ever acquiring p.mu to mark the Pool closed,
+// defeating Shutdown's ctx deadline. Instead Enqueue races the send
+// against p.stopCh, which Shutdown closes, so a racing Shutdown always
+// unblocks it one way or the other.
+func (p *Pool[T]) Enqueue(item T) error {
+	p.mu.Lock()
+	closed := p.closed
+	p.mu.Unlock()
+	if closed {
+		return ErrClosed
+	}
+
+	select {
+	case p.items <- item:
+		return nil
+	case <-p.stopCh:
+		return ErrClosed
+	}
+}
+
+// Shutdown stops the Pool from accepting new items, waits for its queue
+// to drain and any in-flight exportFunc calls to finish, and returns any
+// errors they returned, combined with errors.Join. If ctx is done first,
+// Shutdown returns ctx.Err() joined with whatever errors have been
+// collected so far, without waiting for the remaining in-flight calls.
+func (p *Pool[T]) Shutdown(ctx context.Context) error {
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return ErrClosed
+	}
+	p.closed = true
+	p.mu.Unlock()
+
+	close(p.stopCh)
+
+	select {
+	case <-p.done:
+	case <-ctx.Done():
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		return errors.Join(append([]error{ctx.Err()}, p.errs...)...)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return errors.Join(p.errs...)
+}
+
+// run accumulates items into batches and dispatches each one, until
+// Shutdown has been called and the queue has been drained.
+func (p *Pool[T]) run() {
+	defer close(p.done)
+
+	cfg := p.config.Get()
+	if cfg.MinItems > cfg.MaxItems {
+		cfg.MinItems = cfg.MaxItems
+	}
+	if cfg.MinTime > cfg.MaxTime {
+		cfg.MinTime = cfg.MaxTime
+	}
+
+	for {
+		items, more := p.nextBatch(cfg)
+		if len(items) > 0 {
+			p.dispatch(items)
+		}
+		if !more {
+			break
+		}
+	}
+
+	p.wg.Wait()
+}
+
+// nextBatch accumulates items from p.items until the batch is ready to
+// be processed, as determined by cfg, or until Shutdown is called. The
+// flush timer, armed each time nextBatch starts assembling a new batch,
+// is what MaxTime is measured against; it's equivalent to maxTimer in
+// StreamingBatch.nextBatch, just named for what it does here. It
+// returns the items and whether p.items may still produce more.
+func (p *Pool[T]) nextBatch(cfg batch.ConfigValues) (items []T, more bool) {
+	var minTimer, flushTimer *time.Timer
+	var minTimeC, flushTimeC <-chan time.Time
+
+	if cfg.MinTime > 0 {
+		minTimer = time.NewTimer(cfg.MinTime)
+		defer minTimer.Stop()
+		minTimeC = minTimer.C
+	}
+	if cfg.MaxTime > 0 {
+		flushTimer = time.NewTimer(cfg.MaxTime)
+		defer flushTimer.Stop()
+		flushTimeC = flushTimer.C
+	}
+
+	minTimeReached := cfg.MinTime == 0
+	stopping := false
+
+	for {
+		maxReached := cfg.MaxItems > 0 && uint64(len(items)) >= cfg.MaxItems
+		if maxReached {
+			return items, true
+		}
+
+		minReached := len(items) > 0 && minTimeReached && uint64(len(items)) >= cfg.MinItems
+		if minReached {
+			return items, true
+		}
+
+		if stopping {
+			// Shutdown was called: only drain what's already queued,
+			// without blocking, so the windowing above still caps batch
+			// size instead of lumping every remaining item together.
+			select {
+			case item := <-p.items:
+				items = append(items, item)
+			default:
+				return items, false
+			}
+			continue
+		}
+
+		select {
+		case item := <-p.items:
+			items = append(items, item)
+
+		case <-minTimeC:
+			minTimeReached = true
+			minTimeC = nil
+
+		case <-flushTimeC:
+			return items, true
+
+		case <-p.stopCh:
+			stopping = true
+		}
+	}
+}
+
+// dispatch runs exportFunc on items in its own goroutine, bounded by
+// maxWorkers, collecting any error it returns.
+func (p *Pool[T]) dispatch(items []T) {
+	p.sem <- struct{}{}
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		defer func() { <-p.sem }()
+
+		if err := p.exportFunc(context.Background(), items); err != nil {
+			p.mu.Lock()
+			p.errs = append(p.errs, err)
+			p.mu.Unlock()
+		}
+	}()
+}
@@ -0,0 +1,216 @@
+package pool
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/MasterOfBinary/gobatch/batch"
+)
+
+func TestPoolBatchesByMaxItems(t *testing.T) {
+	var mu sync.Mutex
+	var sizes []int
+
+	config := batch.NewConstantConfig(&batch.ConfigValues{
+		MaxItems: 2,
+		MaxTime:  100 * time.Millisecond,
+	})
+
+	p := New[int](config, 10, 2, func(ctx context.Context, items []int) error {
+		mu.Lock()
+		sizes = append(sizes, len(items))
+		mu.Unlock()
+		return nil
+	})
+
+	for i := 0; i < 5; i++ {
+		if err := p.Enqueue(i); err != nil {
+			t.Fatalf("Enqueue: %v", err)
+		}
+	}
+
+	if err := p.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown: %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	total := 0
+	for _, size := range sizes {
+		if size > 2 {
+			t.Errorf("batch size %d exceeds MaxItems of 2", size)
+		}
+		total += size
+	}
+	if total != 5 {
+		t.Errorf("got %d items processed, want 5", total)
+	}
+}
+
+func TestPoolShutdownCollectsErrors(t *testing.T) {
+	boom := errors.New("boom")
+
+	config := batch.NewConstantConfig(&batch.ConfigValues{
+		MaxItems: 1,
+	})
+
+	p := New[int](config, 10, 4, func(ctx context.Context, items []int) error {
+		return boom
+	})
+
+	for i := 0; i < 3; i++ {
+		if err := p.Enqueue(i); err != nil {
+			t.Fatalf("Enqueue: %v", err)
+		}
+	}
+
+	err := p.Shutdown(context.Background())
+	if err == nil {
+		t.Fatal("Shutdown: got nil error, want boom errors")
+	}
+	if !errors.Is(err, boom) {
+		t.Errorf("Shutdown: got %v, want it to wrap boom", err)
+	}
+}
+
+func TestPoolEnqueueAfterShutdown(t *testing.T) {
+	p := New[int](nil, 1, 1, func(ctx context.Context, items []int) error {
+		return nil
+	})
+
+	if err := p.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown: %v", err)
+	}
+
+	if err := p.Enqueue(1); !errors.Is(err, ErrClosed) {
+		t.Errorf("Enqueue after Shutdown: got %v, want ErrClosed", err)
+	}
+}
+
+func TestPoolShutdownContextTimeout(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+
+	p := New[int](nil, 1, 1, func(ctx context.Context, items []int) error {
+		close(started)
+		<-release
+		return nil
+	})
+
+	if err := p.Enqueue(1); err != nil {
+		t.Fatalf("Enqueue: %v", err)
+	}
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	if err := p.Shutdown(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Shutdown: got %v, want context.DeadlineExceeded", err)
+	}
+
+	close(release)
+}
+
+// TestPoolShutdownContextTimeoutWithBlockedEnqueue reproduces a deadlock
+// where a concurrent Enqueue blocked on a full queue (because the sole
+// worker is stuck in a slow exportFunc call) used to also block Shutdown
+// from ever marking the Pool closed, since both serialized through the
+// same mutex. Shutdown must still honor ctx's deadline in that case.
+func TestPoolShutdownContextTimeoutWithBlockedEnqueue(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+
+	p := New[int](nil, 0, 1, func(ctx context.Context, items []int) error {
+		close(started)
+		<-release
+		return nil
+	})
+
+	if err := p.Enqueue(1); err != nil {
+		t.Fatalf("Enqueue: %v", err)
+	}
+	<-started
+
+	// The sole worker is now busy until release. run still takes one more
+	// item off the unbuffered queue and then blocks in dispatch waiting
+	// for a free worker, so enqueue that item first.
+	if err := p.Enqueue(2); err != nil {
+		t.Fatalf("Enqueue: %v", err)
+	}
+
+	// Nothing is receiving from the queue any more, so this Enqueue call
+	// blocks until Shutdown below closes the Pool.
+	enqueueErr := make(chan error, 1)
+	go func() {
+		enqueueErr <- p.Enqueue(3)
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	if err := p.Shutdown(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Shutdown: got %v, want context.DeadlineExceeded", err)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("Shutdown took %s, want it to honor ctx's 10ms deadline", elapsed)
+	}
+
+	if err := <-enqueueErr; !errors.Is(err, ErrClosed) {
+		t.Errorf("blocked Enqueue: got %v, want ErrClosed", err)
+	}
+
+	close(release)
+}
+
+// TestPoolEnqueueShutdownRace exercises Enqueue racing with Shutdown: every
+// Enqueue call must either land before Shutdown closes the queue or observe
+// ErrClosed, never both return nil and be silently dropped.
+func TestPoolEnqueueShutdownRace(t *testing.T) {
+	var mu sync.Mutex
+	var total int
+
+	p := New[int](nil, 0, 4, func(ctx context.Context, items []int) error {
+		mu.Lock()
+		total += len(items)
+		mu.Unlock()
+		return nil
+	})
+
+	const n = 200
+	var succeeded int64
+	var wg sync.WaitGroup
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		p.Shutdown(context.Background())
+	}()
+
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			switch err := p.Enqueue(i); {
+			case err == nil:
+				atomic.AddInt64(&succeeded, 1)
+			case !errors.Is(err, ErrClosed):
+				t.Errorf("Enqueue: got %v, want nil or ErrClosed", err)
+			}
+		}(i)
+	}
+
+	wg.Wait()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if int64(total) != succeeded {
+		t.Errorf("got %d items processed, want %d (every successful Enqueue should be processed exactly once)", total, succeeded)
+	}
+}
@@ -0,0 +1,16 @@
+// Package processor defines the interface that gobatch uses to process
+// batches of items read from a source.Source.
+package processor
+
+import "context"
+
+// Processor processes items of type T read from a source.Source, sending
+// any errors encountered on errs.
+//
+// Process must return when items is closed, and should return promptly
+// when ctx is done.
+type Processor[T any] interface {
+	// Process reads items from items, processing them however the
+	// implementation sees fit, and sends any errors encountered on errs.
+	Process(ctx context.Context, items <-chan T, errs chan<- error)
+}
@@ -0,0 +1,110 @@
+package gobatch
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"time"
+)
+
+// RetryPolicy configures automatic retries for items that a
+// processor.Processor reports as failed via PartialFailure. It's
+// installed on a StreamingBatch with WithRetryPolicy.
+type RetryPolicy struct {
+	// MaxAttempts is the maximum number of times an item will be given
+	// to Process, including its first attempt. Once an item has been
+	// attempted MaxAttempts times and still fails, it's surfaced as a
+	// RetryExhaustedError.
+	MaxAttempts int
+
+	// BaseDelay is the delay before the first retry. Each subsequent
+	// retry doubles the previous delay.
+	BaseDelay time.Duration
+
+	// MaxDelay caps the delay between retries, regardless of how many
+	// attempts have been made. The cap applies before JitterFraction, so
+	// a jittered delay can exceed MaxDelay by up to that fraction.
+	MaxDelay time.Duration
+
+	// JitterFraction randomizes each delay by up to this fraction in
+	// either direction, e.g. 0.1 means the delay is adjusted by up to
+	// 10% higher or lower. It's ignored if <= 0.
+	JitterFraction float64
+
+	// Retryable reports whether err should be retried. If nil, every
+	// error is considered retryable.
+	Retryable func(err error) bool
+}
+
+// delay returns how long to wait before retrying an item that has
+// already been attempted attempt times (attempt is 1 after the first
+// failure).
+func (p RetryPolicy) delay(attempt int) time.Duration {
+	d := float64(p.BaseDelay) * math.Pow(2, float64(attempt-1))
+	if p.MaxDelay > 0 && d > float64(p.MaxDelay) {
+		d = float64(p.MaxDelay)
+	}
+
+	if p.JitterFraction > 0 {
+		jitter := (rand.Float64()*2 - 1) * p.JitterFraction
+		d += d * jitter
+		if d < 0 {
+			d = 0
+		}
+	}
+
+	return time.Duration(d)
+}
+
+func (p RetryPolicy) retryable(err error) bool {
+	if p.Retryable == nil {
+		return true
+	}
+	return p.Retryable(err)
+}
+
+// PartialFailure is the error a processor.Processor sends on errs to
+// indicate that only some of the items in a batch failed. Indices refers
+// to the position of each failed item within the batch passed to
+// Process, in receive order starting at 0. Err describes the failure.
+//
+// When a RetryPolicy is configured, StreamingBatch recognizes
+// PartialFailure and retries only the indicated items instead of the
+// whole batch.
+type PartialFailure struct {
+	Err     error
+	Indices []int
+}
+
+// Error implements the error interface.
+func (e *PartialFailure) Error() string {
+	return fmt.Sprintf("gobatch: %d item(s) failed: %v", len(e.Indices), e.Err)
+}
+
+// Unwrap returns e.Err, allowing PartialFailure to be used with
+// errors.Is and errors.As.
+func (e *PartialFailure) Unwrap() error {
+	return e.Err
+}
+
+// RetryExhaustedError is sent on errs when an item has failed
+// RetryPolicy.MaxAttempts times, or failed with an error that
+// RetryPolicy.Retryable rejected. Item is the value that failed, and Err
+// is the error from its last attempt. It's also sent for every item still
+// awaiting a retry when the context passed to Go is canceled; Err is then
+// the context's error.
+type RetryExhaustedError[T any] struct {
+	Item T
+	Err  error
+}
+
+// Error implements the error interface.
+func (e *RetryExhaustedError[T]) Error() string {
+	return fmt.Sprintf("gobatch: retries exhausted: %v", e.Err)
+}
+
+// Unwrap returns e.Err, allowing RetryExhaustedError to be used with
+// errors.Is and errors.As.
+func (e *RetryExhaustedError[T]) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,38 @@
+// Package source defines the interface that gobatch uses to read items
+// for processing.
+package source
+
+import "context"
+
+// Source reads items of type T and sends them on items for processing by a
+// processor.Processor. Errors that occur while reading are sent on errs.
+//
+// Read must close both items and errs before returning, and must return
+// when ctx is done. Once Read returns, gobatch assumes no more items will
+// be produced.
+type Source[T any] interface {
+	// Read sends items read from the source on items, and any errors
+	// encountered on errs. Read must close both channels before
+	// returning.
+	Read(ctx context.Context, items chan<- T, errs chan<- error)
+}
+
+// Checkpointable is implemented by a Source that can report a stable
+// position of type P for each item it produces, such as an offset or an
+// ID that increases monotonically in the order items are read. It lets
+// gobatch track and persist how far a run has progressed with a
+// checkpoint.Checkpointer.
+type Checkpointable[T, P any] interface {
+	// Position returns the position of item. Positions must increase
+	// monotonically in the order Read produces items.
+	Position(item T) P
+}
+
+// Seekable is implemented by a Source that can resume reading after a
+// previously checkpointed position, instead of relying on the caller to
+// discard already-processed items itself.
+type Seekable[P any] interface {
+	// SeekTo instructs the Source to begin reading after pos the next
+	// time Read is called.
+	SeekTo(ctx context.Context, pos P) error
+}
@@ -0,0 +1,40 @@
+package gobatch
+
+import (
+	"fmt"
+	"time"
+)
+
+// SourceStalledError is sent on errs when a call to source.Source.Read
+// makes no progress (sends no item and no error) for SourceReadTimeout.
+// The stalled call's context is canceled and Read is called again from
+// scratch; it's up to the Source to handle the canceled context and
+// return.
+type SourceStalledError struct {
+	// Timeout is the SourceReadTimeout that elapsed.
+	Timeout time.Duration
+}
+
+// Error implements the error interface.
+func (e *SourceStalledError) Error() string {
+	return fmt.Sprintf("gobatch: source read stalled for %s, restarting", e.Timeout)
+}
+
+// PanicError is sent on errs when a panic is recovered from a
+// source.Source or processor.Processor call, in place of crashing the
+// program. A panic while processing one batch doesn't stop the others
+// from being processed; a panic outside of batch processing itself
+// (e.g. in nextBatch or a Sizer) ends the current run, but is still
+// reported as a PanicError rather than crashing the program.
+type PanicError struct {
+	// Value is the value passed to panic.
+	Value any
+
+	// Stack is the stack trace captured where the panic was recovered.
+	Stack []byte
+}
+
+// Error implements the error interface.
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("gobatch: recovered from panic: %v", e.Value)
+}